@@ -0,0 +1,229 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+
+	"github.com/logrusorgru/aurora/v3"
+	"sigs.k8s.io/yaml"
+)
+
+// Drift kinds, stored in Probe.Drift for entries with Category ==
+// ProbeCategoryDrift.
+const (
+	DriftRegressed = "regressed"
+	DriftAdded     = "added"
+	DriftRemoved   = "removed"
+	DriftChanged   = "changed"
+)
+
+// baselineFile is the format written by --save-baseline and read back by
+// --baseline: a small header identifying where and when it was captured,
+// plus the full set of probe results at that point in time.
+type baselineFile struct {
+	K0sVersion string    `json:"k0sVersion"`
+	Hostname   string    `json:"hostname"`
+	Kernel     string    `json:"kernel"`
+	Timestamp  time.Time `json:"timestamp"`
+	Probes     []Probe   `json:"probes"`
+}
+
+func saveBaseline(path string, results []Probe) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	baseline := baselineFile{
+		K0sVersion: constant.Version,
+		Hostname:   hostname,
+		Kernel:     kernelRelease(),
+		Timestamp:  time.Now(),
+		Probes:     results,
+	}
+
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline %q: %w", path, err)
+	}
+	return nil
+}
+
+func loadBaseline(path string) (*baselineFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %q: %w", path, err)
+	}
+
+	var baseline baselineFile
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %q: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+func kernelRelease() string {
+	out, err := exec.Command("uname", "-r").Output()
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// probeKey uniquely identifies a probe result across runs, independent of
+// its current category or value, as specified by the request: the joined
+// path plus the display name.
+func probeKey(p Probe) string {
+	return strings.Join(p.Path, "/") + p.DisplayName
+}
+
+// diffBaseline compares a baseline against the current probe results and
+// returns the drift entries: probes that regressed from pass to
+// warning/rejected/error, probes that are new or missing, and passing
+// probes whose value changed.
+func diffBaseline(baseline, current []Probe) []Probe {
+	baselineByKey := make(map[string]Probe, len(baseline))
+	for _, p := range baseline {
+		baselineByKey[probeKey(p)] = p
+	}
+
+	seen := make(map[string]bool, len(current))
+	var drift []Probe
+
+	for _, cur := range current {
+		key := probeKey(cur)
+		seen[key] = true
+
+		old, ok := baselineByKey[key]
+		if !ok {
+			drift = append(drift, driftEntry(cur, DriftAdded, ""))
+			continue
+		}
+
+		if regressed(old.Category, cur.Category) {
+			drift = append(drift, driftEntry(cur, DriftRegressed, old.Prop))
+			continue
+		}
+
+		if old.Category == ProbeCategoryPass && cur.Category == ProbeCategoryPass && old.Prop != cur.Prop {
+			drift = append(drift, driftEntry(cur, DriftChanged, old.Prop))
+		}
+	}
+
+	for _, old := range baseline {
+		if !seen[probeKey(old)] {
+			drift = append(drift, driftEntry(old, DriftRemoved, old.Prop))
+		}
+	}
+
+	return drift
+}
+
+func regressed(old, cur ProbeCategory) bool {
+	if old != ProbeCategoryPass {
+		return false
+	}
+	return cur == ProbeCategoryWarning || cur == ProbeCategoryRejected || cur == ProbeCategoryError
+}
+
+func driftEntry(p Probe, kind, baselineProp string) Probe {
+	p.Category = ProbeCategoryDrift
+	p.Drift = kind
+	p.BaselineProp = baselineProp
+	return p
+}
+
+// hasRegression reports whether any drift entry represents a regression or
+// a probe that disappeared entirely, the signal that should fail a
+// CI/CronJob run.
+func hasRegression(drift []Probe) bool {
+	for _, p := range drift {
+		if p.Drift == DriftRegressed || p.Drift == DriftRemoved {
+			return true
+		}
+	}
+	return false
+}
+
+// printDrift renders the drift entries in the requested output format,
+// reusing the same json/yaml marshalling used for plain probe results.
+func printDrift(drift []Probe, out io.Writer, format string, colors aurora.Aurora) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(drift, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+
+	case "yaml":
+		data, err := yaml.Marshal(drift)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+
+	case "text":
+		return printDriftText(drift, out, colors)
+
+	default:
+		return fmt.Errorf("unknown output format: %q", format)
+	}
+}
+
+func printDriftText(drift []Probe, out io.Writer, colors aurora.Aurora) error {
+	for _, p := range drift {
+		name := p.DisplayName
+		if len(p.Path) > 0 {
+			name = strings.Join(p.Path, "/") + "/" + name
+		}
+
+		var line string
+		switch p.Drift {
+		case DriftRegressed:
+			line = fmt.Sprint(colors.Red(fmt.Sprintf("%s: regressed (was %q, now %q)", name, p.BaselineProp, p.Prop)))
+		case DriftRemoved:
+			line = fmt.Sprint(colors.Red(fmt.Sprintf("%s: removed (was %q)", name, p.BaselineProp)))
+		case DriftChanged:
+			line = fmt.Sprint(colors.Yellow(fmt.Sprintf("%s: changed (%q -> %q)", name, p.BaselineProp, p.Prop)))
+		case DriftAdded:
+			line = fmt.Sprint(colors.Cyan(fmt.Sprintf("%s: added (%q)", name, p.Prop)))
+		default:
+			line = fmt.Sprintf("%s: %s", name, p.Drift)
+		}
+
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
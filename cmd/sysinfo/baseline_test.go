@@ -0,0 +1,90 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+func TestDiffBaseline(t *testing.T) {
+	baseline := []Probe{
+		{Path: []string{"kernel"}, DisplayName: "version", Prop: "5.15.0", Category: ProbeCategoryPass},
+		{Path: []string{"memory"}, DisplayName: "total", Prop: "32Gi", Category: ProbeCategoryPass},
+		{Path: []string{"cgroups"}, DisplayName: "version", Prop: "2", Category: ProbeCategoryPass},
+	}
+	current := []Probe{
+		{Path: []string{"kernel"}, DisplayName: "version", Prop: "5.15.1", Category: ProbeCategoryPass}, // changed
+		{Path: []string{"cgroups"}, DisplayName: "version", Prop: "1", Category: ProbeCategoryRejected}, // regressed
+		{Path: []string{"disk"}, DisplayName: "free", Prop: "100Gi", Category: ProbeCategoryPass},       // added
+		// "memory" is missing from current -> removed
+	}
+
+	drift := diffBaseline(baseline, current)
+
+	byKind := make(map[string]Probe)
+	for _, d := range drift {
+		byKind[d.Drift] = d
+	}
+
+	if len(drift) != 4 {
+		t.Fatalf("expected 4 drift entries, got %d: %+v", len(drift), drift)
+	}
+
+	changed, ok := byKind[DriftChanged]
+	if !ok || changed.BaselineProp != "5.15.0" || changed.Prop != "5.15.1" {
+		t.Errorf("unexpected changed entry: %+v", changed)
+	}
+
+	regressed, ok := byKind[DriftRegressed]
+	if !ok || regressed.BaselineProp != "2" || regressed.Prop != "1" {
+		t.Errorf("unexpected regressed entry: %+v", regressed)
+	}
+
+	added, ok := byKind[DriftAdded]
+	if !ok || added.Prop != "100Gi" {
+		t.Errorf("unexpected added entry: %+v", added)
+	}
+
+	removed, ok := byKind[DriftRemoved]
+	if !ok || removed.BaselineProp != "32Gi" {
+		t.Errorf("removed entry should carry the last-known value, got: %+v", removed)
+	}
+
+	if !hasRegression(drift) {
+		t.Error("expected hasRegression to be true")
+	}
+}
+
+func TestPrintDriftTextShowsLastKnownValueForRemoved(t *testing.T) {
+	drift := []Probe{
+		{Path: []string{"memory"}, DisplayName: "total", Category: ProbeCategoryDrift, Drift: DriftRemoved, BaselineProp: "32Gi"},
+	}
+
+	var buf bytes.Buffer
+	if err := printDriftText(drift, &buf, aurora.NewAurora(false)); err != nil {
+		t.Fatalf("printDriftText failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `was "32Gi"`) {
+		t.Fatalf("expected removed entry to show its last-known value, got: %q", out)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// policyCategorySkip is the extra category a policy may return on top of the
+// regular ProbeCategory values to drop a result from the report entirely.
+const policyCategorySkip ProbeCategory = "skip"
+
+const policyDecisionQuery = "data.k0s.sysinfo.decision"
+
+// policy re-grades probe results against an operator-supplied Rego policy
+// bundle, so that pass/warn/reject thresholds can be tightened (or relaxed)
+// without patching the probes themselves.
+type policy struct {
+	query rego.PreparedEvalQuery
+}
+
+// loadPolicy compiles the policy bundle at location, which may be a single
+// .rego file, a directory containing a bundle-style layout (.rego files plus
+// data.json), or an http(s) URL pointing at a single .rego module. It
+// returns a nil policy (and no error) when location is empty, so callers can
+// unconditionally wrap their reporter with the result.
+func loadPolicy(ctx context.Context, location string) (*policy, error) {
+	if location == "" {
+		return nil, nil
+	}
+
+	var opts []func(*rego.Rego)
+	switch {
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		module, err := fetchPolicyModule(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, rego.Module(location, module))
+
+	default:
+		if _, err := os.Stat(location); err != nil {
+			return nil, fmt.Errorf("failed to access policy bundle %q: %w", location, err)
+		}
+		opts = append(opts, rego.Load([]string{location}, nil))
+	}
+
+	opts = append(opts, rego.Query(policyDecisionQuery))
+
+	query, err := rego.New(opts...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile sysinfo policy %q: %w", location, err)
+	}
+
+	return &policy{query: query}, nil
+}
+
+func fetchPolicyModule(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request for policy %q: %w", url, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch policy %q: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch policy %q: unexpected status %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read policy %q: %w", url, err)
+	}
+
+	return string(body), nil
+}
+
+// evaluate asks the policy how a probe result should be graded. When the
+// policy has no applicable rule for the input, the probe's own category and
+// message are kept unchanged.
+func (p *policy) evaluate(ctx context.Context, probe probes.ProbeDesc, prop probes.ProbedProp, category ProbeCategory, msg string) (ProbeCategory, string, error) {
+	input := map[string]interface{}{
+		"path":        probe.Path(),
+		"displayName": probe.DisplayName(),
+		"prop":        propString(prop),
+		"category":    string(category),
+		"message":     msg,
+	}
+
+	rs, err := p.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return "", "", fmt.Errorf("policy evaluation failed for %q: %w", probe.DisplayName(), err)
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return category, msg, nil
+	}
+
+	decision, ok := rs[0].Expressions[0].Value.(map[string]interface{})
+	if !ok {
+		return "", "", fmt.Errorf("policy for %q returned an unexpected result shape", probe.DisplayName())
+	}
+
+	newCategory, _ := decision["category"].(string)
+	newMessage, _ := decision["message"].(string)
+
+	switch ProbeCategory(newCategory) {
+	case ProbeCategoryPass, ProbeCategoryWarning, ProbeCategoryRejected, ProbeCategoryError, policyCategorySkip:
+		return ProbeCategory(newCategory), newMessage, nil
+	default:
+		return "", "", fmt.Errorf("policy for %q returned unknown category %q", probe.DisplayName(), newCategory)
+	}
+}
+
+// policyReporter wraps another probes.Reporter and re-grades every result
+// through policy before forwarding it. With a nil policy it is a pure
+// pass-through, so callers can wrap unconditionally.
+type policyReporter struct {
+	next   probes.Reporter
+	policy *policy
+	failed bool
+}
+
+func (r *policyReporter) Pass(p probes.ProbeDesc, v probes.ProbedProp) error {
+	return r.apply(p, v, ProbeCategoryPass, "")
+}
+
+func (r *policyReporter) Warn(p probes.ProbeDesc, v probes.ProbedProp, msg string) error {
+	return r.apply(p, v, ProbeCategoryWarning, msg)
+}
+
+func (r *policyReporter) Reject(p probes.ProbeDesc, v probes.ProbedProp, msg string) error {
+	return r.apply(p, v, ProbeCategoryRejected, msg)
+}
+
+func (r *policyReporter) Error(p probes.ProbeDesc, err error) error {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	return r.apply(p, nil, ProbeCategoryError, msg)
+}
+
+func (r *policyReporter) apply(p probes.ProbeDesc, v probes.ProbedProp, category ProbeCategory, msg string) error {
+	if r.policy != nil {
+		newCategory, newMsg, err := r.policy.evaluate(context.Background(), p, v, category, msg)
+		if err != nil {
+			return err
+		}
+		category, msg = newCategory, newMsg
+	}
+
+	switch category {
+	case policyCategorySkip:
+		return nil
+
+	case ProbeCategoryPass:
+		return r.next.Pass(p, v)
+
+	case ProbeCategoryWarning:
+		return r.next.Warn(p, v, msg)
+
+	case ProbeCategoryRejected:
+		r.failed = true
+		return r.next.Reject(p, v, msg)
+
+	case ProbeCategoryError:
+		r.failed = true
+		var err error
+		if msg != "" {
+			err = errors.New(msg)
+		}
+		return r.next.Error(p, err)
+
+	default:
+		return fmt.Errorf("policy returned unknown probe category %q", category)
+	}
+}
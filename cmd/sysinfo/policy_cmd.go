@@ -0,0 +1,105 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage sysinfo probe policies",
+	}
+
+	cmd.AddCommand(newPolicyTestCmd())
+
+	return cmd
+}
+
+func newPolicyTestCmd() *cobra.Command {
+	var policyLocation string
+
+	cmd := &cobra.Command{
+		Use:   "test <results-file>",
+		Short: "Re-grade a captured sysinfo result file against a policy",
+		Long: `Evaluates a Rego policy bundle against the probe results captured by a
+previous "k0s sysinfo -o json" run, without re-running the probes. Intended
+for exercising policy changes in CI before rolling them out to nodes.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if policyLocation == "" {
+				return errors.New("--policy is required")
+			}
+
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read results file %q: %w", args[0], err)
+			}
+
+			var results []Probe
+			if err := json.Unmarshal(data, &results); err != nil {
+				return fmt.Errorf("failed to parse results file %q: %w", args[0], err)
+			}
+
+			pol, err := loadPolicy(cmd.Context(), policyLocation)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			failed := false
+			for _, p := range results {
+				category, _, err := pol.evaluate(cmd.Context(), staticProbeDesc{p}, staticProbedProp(p.Prop), p.Category, p.Message)
+				if err != nil {
+					return err
+				}
+				if category == ProbeCategoryRejected || category == ProbeCategoryError {
+					failed = true
+				}
+				fmt.Fprintf(out, "%s: %s (%s)\n", p.DisplayName, p.Prop, category)
+			}
+
+			if failed {
+				return errors.New("sysinfo policy test failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&policyLocation, "policy", "", "Path, directory or URL of the Rego policy bundle to test")
+
+	return cmd
+}
+
+// staticProbeDesc adapts a captured Probe result back into a probes.ProbeDesc
+// so that a policy can be evaluated against it without re-running probes.
+type staticProbeDesc struct{ p Probe }
+
+func (s staticProbeDesc) Path() []string      { return s.p.Path }
+func (s staticProbeDesc) DisplayName() string { return s.p.DisplayName }
+
+// staticProbedProp adapts a captured prop string back into a
+// probes.ProbedProp.
+type staticProbedProp string
+
+func (p staticProbedProp) String() string { return string(p) }
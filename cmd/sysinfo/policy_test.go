@@ -0,0 +1,168 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+const testPolicyModule = `
+package k0s.sysinfo
+
+decision = {"category": "rejected", "message": "at least 32Gi of RAM is required"} {
+	input.path == ["memory", "total"]
+	input.category == "pass"
+}
+
+decision = {"category": "skip"} {
+	input.path == ["debug", "noisy"]
+}
+`
+
+func mustCompilePolicy(t *testing.T) *policy {
+	t.Helper()
+
+	query, err := rego.New(
+		rego.Query(policyDecisionQuery),
+		rego.Module("test.rego", testPolicyModule),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		t.Fatalf("failed to compile test policy: %v", err)
+	}
+	return &policy{query: query}
+}
+
+type fakeProbeDesc struct {
+	path []string
+	name string
+}
+
+func (p fakeProbeDesc) Path() []string      { return p.path }
+func (p fakeProbeDesc) DisplayName() string { return p.name }
+
+func TestPolicyEvaluateOverridesCategory(t *testing.T) {
+	pol := mustCompilePolicy(t)
+
+	category, msg, err := pol.evaluate(context.Background(),
+		fakeProbeDesc{path: []string{"memory", "total"}, name: "Total memory"}, nil, ProbeCategoryPass, "")
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if category != ProbeCategoryRejected {
+		t.Fatalf("expected policy to downgrade to rejected, got %q", category)
+	}
+	if msg == "" {
+		t.Fatal("expected a message explaining the rejection")
+	}
+}
+
+func TestPolicyEvaluateSkip(t *testing.T) {
+	pol := mustCompilePolicy(t)
+
+	category, _, err := pol.evaluate(context.Background(),
+		fakeProbeDesc{path: []string{"debug", "noisy"}, name: "Noisy debug probe"}, nil, ProbeCategoryWarning, "")
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if category != policyCategorySkip {
+		t.Fatalf("expected skip, got %q", category)
+	}
+}
+
+func TestPolicyEvaluateNoRuleKeepsOriginal(t *testing.T) {
+	pol := mustCompilePolicy(t)
+
+	category, msg, err := pol.evaluate(context.Background(),
+		fakeProbeDesc{path: []string{"kernel", "version"}, name: "Kernel version"}, nil, ProbeCategoryWarning, "some message")
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+	if category != ProbeCategoryWarning || msg != "some message" {
+		t.Fatalf("expected original category/message to be kept, got %q/%q", category, msg)
+	}
+}
+
+// countingReporter implements probes.Reporter, tallying how many times
+// each method is called so tests can assert on policyReporter's dispatch
+// after it rewrites a result's category.
+type countingReporter struct {
+	passed, warned, rejected, errored int
+}
+
+func (c *countingReporter) Pass(probes.ProbeDesc, probes.ProbedProp) error {
+	c.passed++
+	return nil
+}
+
+func (c *countingReporter) Warn(probes.ProbeDesc, probes.ProbedProp, string) error {
+	c.warned++
+	return nil
+}
+
+func (c *countingReporter) Reject(probes.ProbeDesc, probes.ProbedProp, string) error {
+	c.rejected++
+	return nil
+}
+
+func (c *countingReporter) Error(probes.ProbeDesc, error) error {
+	c.errored++
+	return errors.New("unexpected error call")
+}
+
+func TestPolicyReporterSkipDropsResult(t *testing.T) {
+	pol := mustCompilePolicy(t)
+
+	next := &countingReporter{}
+	r := &policyReporter{next: next, policy: pol}
+
+	desc := fakeProbeDesc{path: []string{"debug", "noisy"}, name: "Noisy debug probe"}
+	if err := r.Warn(desc, nil, "ignored"); err != nil {
+		t.Fatalf("Warn failed: %v", err)
+	}
+
+	if next.passed+next.warned+next.rejected+next.errored != 0 {
+		t.Fatalf("expected the skip to drop the result, got %+v", next)
+	}
+	if r.failed {
+		t.Fatal("a skipped result must not mark the reporter as failed")
+	}
+}
+
+func TestPolicyReporterRejectMarksFailed(t *testing.T) {
+	pol := mustCompilePolicy(t)
+
+	next := &countingReporter{}
+	r := &policyReporter{next: next, policy: pol}
+
+	desc := fakeProbeDesc{path: []string{"memory", "total"}, name: "Total memory"}
+	if err := r.Pass(desc, nil); err != nil {
+		t.Fatalf("Pass failed: %v", err)
+	}
+
+	if !r.failed {
+		t.Fatal("expected policy downgrade to pass->rejected to mark the reporter as failed")
+	}
+	if next.rejected != 1 {
+		t.Fatalf("expected the downgraded result to be forwarded as a Reject, got %+v", next)
+	}
+}
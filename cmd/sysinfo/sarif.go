@@ -0,0 +1,224 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// SARIF (Static Analysis Results Interchange Format) lets sysinfo findings be
+// consumed by the same code-scanning dashboards used for vulnerability
+// reports, e.g. via github/codeql-action/upload-sarif.
+const (
+	sarifSchemaURI = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+	sarifVersion   = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name            string      `json:"name"`
+	SemanticVersion string      `json:"semanticVersion"`
+	Rules           []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	ShortDescription sarifText `json:"shortDescription"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID       string            `json:"ruleId"`
+	Level        string            `json:"level"`
+	Message      sarifText         `json:"message"`
+	Locations    []sarifLocation   `json:"locations"`
+	Fingerprints map[string]string `json:"fingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// collectAndPrintSarif runs the given probes, renders the results as a
+// single-run SARIF log and writes it to out. Like collectAndPrint, it
+// reports sysinfo failures via the returned error, but unlike json/yaml
+// output it writes the log regardless of failure, since the whole point of
+// the SARIF output is to surface rejected/errored probes to a scanning UI.
+func collectAndPrintSarif(probe probes.Probe, out io.Writer, pol *policy) error {
+	var c resultsCollector
+	pr := &policyReporter{next: &c, policy: pol}
+	if err := probe.Probe(pr); err != nil {
+		return err
+	}
+
+	if err := writeSarif(c.results, out); err != nil {
+		return err
+	}
+
+	if pr.failed {
+		return errors.New("sysinfo failed")
+	}
+	return nil
+}
+
+func writeSarif(results []Probe, out io.Writer) error {
+	rules := make(map[string]sarifRule)
+	sarifResults := make([]sarifResult, 0, len(results))
+
+	for _, p := range results {
+		ruleID := sarifRuleID(p.Path)
+		if _, ok := rules[ruleID]; !ok {
+			rules[ruleID] = sarifRule{
+				ID:               ruleID,
+				Name:             p.DisplayName,
+				ShortDescription: sarifText{Text: p.DisplayName},
+			}
+		}
+
+		sarifResults = append(sarifResults, sarifResult{
+			RuleID: ruleID,
+			Level:  sarifLevel(p.Category),
+			Message: sarifText{
+				Text: sarifMessageText(p),
+			},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifArtifactURI(p.Path)},
+				},
+			}},
+			Fingerprints: map[string]string{
+				"k0s/v1": sarifFingerprint(p.Path, p.DisplayName),
+			},
+		})
+	}
+
+	ruleList := make([]sarifRule, 0, len(rules))
+	for _, r := range rules {
+		ruleList = append(ruleList, r)
+	}
+	sort.Slice(ruleList, func(i, j int) bool { return ruleList[i].ID < ruleList[j].ID })
+
+	log := sarifLog{
+		Schema:  sarifSchemaURI,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:            "k0s",
+					SemanticVersion: constant.Version,
+					Rules:           ruleList,
+				},
+			},
+			Results: sarifResults,
+		}},
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+// sarifLevel maps a ProbeCategory to the SARIF result level vocabulary.
+func sarifLevel(category ProbeCategory) string {
+	switch category {
+	case ProbeCategoryPass:
+		return "none"
+	case ProbeCategoryWarning:
+		return "warning"
+	case ProbeCategoryRejected, ProbeCategoryError:
+		return "error"
+	default:
+		return "none"
+	}
+}
+
+func sarifRuleID(path []string) string {
+	if len(path) == 0 {
+		return "k0s.sysinfo"
+	}
+	return "k0s." + strings.Join(path, ".")
+}
+
+func sarifMessageText(p Probe) string {
+	msg := p.DisplayName
+	if p.Prop != "" {
+		msg = fmt.Sprintf("%s: %s", msg, p.Prop)
+	}
+	if p.Message != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, p.Message)
+	}
+	return msg
+}
+
+func sarifArtifactURI(path []string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("sysinfo://%s/%s", host, strings.Join(path, "/"))
+}
+
+// sarifFingerprint derives a fingerprint for a probe's identity (its path
+// plus display name) so the same logical finding keeps the same
+// fingerprint across runs, letting a SARIF consumer track its remediation
+// over time. It deliberately excludes the probe's current value: that
+// fluctuates run to run (a kernel patch bump, a slightly different free
+// memory reading) without the finding itself being new.
+func sarifFingerprint(path []string, displayName string) string {
+	h := sha256.New()
+	h.Write([]byte(strings.Join(path, "/")))
+	h.Write([]byte{'|'})
+	h.Write([]byte(displayName))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import "testing"
+
+func TestSarifFingerprintStableAcrossPropChanges(t *testing.T) {
+	path := []string{"kernel", "version"}
+	displayName := "Kernel version"
+
+	before := sarifFingerprint(path, displayName)
+	after := sarifFingerprint(path, displayName)
+
+	if before != after {
+		t.Fatalf("fingerprint should be deterministic, got %q and %q", before, after)
+	}
+
+	// The whole point of the fingerprint is to track the same finding
+	// across runs where the measured value fluctuates without the check
+	// itself changing (a kernel patch bump, a different free-memory
+	// reading, ...). Varying the display name, which is what we key on,
+	// must still change it.
+	other := sarifFingerprint(path, "Something else")
+	if before == other {
+		t.Fatalf("fingerprint should differ for a different probe")
+	}
+}
+
+func TestWriteSarifLevelMapping(t *testing.T) {
+	cases := []struct {
+		category ProbeCategory
+		want     string
+	}{
+		{ProbeCategoryPass, "none"},
+		{ProbeCategoryWarning, "warning"},
+		{ProbeCategoryRejected, "error"},
+		{ProbeCategoryError, "error"},
+	}
+
+	for _, c := range cases {
+		if got := sarifLevel(c.category); got != c.want {
+			t.Errorf("sarifLevel(%q) = %q, want %q", c.category, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo"
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+	"github.com/k0sproject/k0s/pkg/constant"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd() *cobra.Command {
+	var sysinfoSpec sysinfo.K0sSysinfoSpec
+	var addr string
+	var interval time.Duration
+	var policyLocation string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve sysinfo probe results over HTTP",
+		Long: `Runs the sysinfo probes periodically and serves the results over HTTP,
+so that long-running nodes can be monitored for preflight drift (e.g. a
+kernel upgrade that breaks cgroup v2 requirements) instead of only at
+install time.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sysinfoSpec.AddDebugProbes = true
+
+			pol, err := loadPolicy(cmd.Context(), policyLocation)
+			if err != nil {
+				return err
+			}
+
+			srv := newSysinfoServer(&sysinfoSpec, pol)
+
+			ctx := cmd.Context()
+			go srv.runPeriodically(ctx, interval)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/sysinfo", srv.handleSysinfo)
+			mux.HandleFunc("/sysinfo/stream", srv.handleStream)
+			mux.Handle("/metrics", promhttp.HandlerFor(srv.registry, promhttp.HandlerOpts{}))
+
+			httpServer := &http.Server{Addr: addr, Handler: mux}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = httpServer.Shutdown(shutdownCtx)
+			}()
+
+			logrus.Infof("sysinfo: serving on %s (re-probing every %s)", addr, interval)
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&sysinfoSpec.ControllerRoleEnabled, "controller", true, "Include controller-specific sysinfo")
+	flags.BoolVar(&sysinfoSpec.WorkerRoleEnabled, "worker", true, "Include worker-specific sysinfo")
+	flags.StringVar(&sysinfoSpec.DataDir, "data-dir", constant.DataDirDefault, "Data Directory for k0s")
+	flags.StringVar(&addr, "addr", ":9500", "Address to serve sysinfo results on")
+	flags.DurationVar(&interval, "interval", 5*time.Minute, "How often to re-run the probes")
+	flags.StringVar(&policyLocation, "policy", "", "Rego policy bundle (file, directory, or URL) to re-grade probe results")
+
+	return cmd
+}
+
+// sysinfoServer keeps the most recently probed results around so that
+// GET /sysinfo and GET /metrics can answer instantly, while GET
+// /sysinfo/stream always triggers (and streams) a fresh probe run.
+type sysinfoServer struct {
+	spec   *sysinfo.K0sSysinfoSpec
+	policy *policy
+
+	mu     sync.RWMutex
+	latest []Probe
+	failed bool
+
+	registry    *prometheus.Registry
+	probeTotal  *prometheus.CounterVec
+	failedGauge prometheus.Gauge
+}
+
+func newSysinfoServer(spec *sysinfo.K0sSysinfoSpec, pol *policy) *sysinfoServer {
+	probeTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "k0s_sysinfo_probe_total",
+		Help: "Number of sysinfo probe results observed, by probe path and category.",
+	}, []string{"path", "category"})
+
+	failedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "k0s_sysinfo_failed",
+		Help: "1 if the most recent sysinfo run had a rejected or errored probe, 0 otherwise.",
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(probeTotal, failedGauge)
+
+	return &sysinfoServer{
+		spec:        spec,
+		policy:      pol,
+		registry:    registry,
+		probeTotal:  probeTotal,
+		failedGauge: failedGauge,
+	}
+}
+
+// runPeriodically re-probes every interval until ctx is cancelled, updating
+// the cached results used by handleSysinfo and the metrics served on /metrics.
+func (s *sysinfoServer) runPeriodically(ctx context.Context, interval time.Duration) {
+	s.probeOnce()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.probeOnce()
+		}
+	}
+}
+
+func (s *sysinfoServer) probeOnce() {
+	var c resultsCollector
+	reporter := &policyReporter{next: &c, policy: s.policy}
+	if err := s.spec.NewSysinfoProbes().Probe(reporter); err != nil {
+		logrus.WithError(err).Error("sysinfo: probe run failed")
+		return
+	}
+
+	s.mu.Lock()
+	s.latest = c.results
+	s.failed = reporter.failed
+	s.mu.Unlock()
+
+	// k0s_sysinfo_probe_total is a counter: it must only ever increase, or
+	// rate()/increase() queries (and any alerting built on them) will read
+	// a Reset() as a process restart. Every re-probe tick just adds to it.
+	for _, p := range c.results {
+		s.probeTotal.WithLabelValues(probePathString(p.Path), string(p.Category)).Inc()
+	}
+	if reporter.failed {
+		s.failedGauge.Set(1)
+	} else {
+		s.failedGauge.Set(0)
+	}
+}
+
+func (s *sysinfoServer) handleSysinfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	results, failed := s.latest, s.failed
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if failed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(results)
+}
+
+func (s *sysinfoServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	reporter := &policyReporter{
+		next:   &streamReporter{w: w, flusher: flusher},
+		policy: s.policy,
+	}
+	if err := s.spec.NewSysinfoProbes().Probe(reporter); err != nil {
+		logrus.WithError(err).Error("sysinfo: streaming probe run failed")
+	}
+}
+
+// streamReporter writes each probe result as a line of newline-delimited
+// JSON and flushes it immediately, so a client sees results as they land
+// rather than waiting for the whole run to finish.
+type streamReporter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *streamReporter) Pass(p probes.ProbeDesc, v probes.ProbedProp) error {
+	return s.write(Probe{Path: probePath(p), DisplayName: p.DisplayName(), Prop: propString(v), Category: ProbeCategoryPass})
+}
+
+func (s *streamReporter) Warn(p probes.ProbeDesc, v probes.ProbedProp, msg string) error {
+	return s.write(Probe{Path: probePath(p), DisplayName: p.DisplayName(), Prop: propString(v), Message: msg, Category: ProbeCategoryWarning})
+}
+
+func (s *streamReporter) Reject(p probes.ProbeDesc, v probes.ProbedProp, msg string) error {
+	return s.write(Probe{Path: probePath(p), DisplayName: p.DisplayName(), Prop: propString(v), Message: msg, Category: ProbeCategoryRejected})
+}
+
+func (s *streamReporter) Error(p probes.ProbeDesc, err error) error {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	return s.write(Probe{Path: probePath(p), DisplayName: p.DisplayName(), Message: msg, Category: ProbeCategoryError})
+}
+
+func (s *streamReporter) write(p Probe) error {
+	if err := json.NewEncoder(s.w).Encode(p); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func probePathString(path []string) string {
+	return "/" + strings.Join(path, "/")
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"testing"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestProbeTotalIsCumulative increments k0s_sysinfo_probe_total the same way
+// probeOnce does, once per simulated tick, and checks that the value
+// accumulates across ticks. It does not call probeOnce itself.
+func TestProbeTotalIsCumulative(t *testing.T) {
+	srv := newSysinfoServer(&sysinfo.K0sSysinfoSpec{}, nil)
+
+	results := []Probe{{Path: []string{"kernel"}, Category: ProbeCategoryPass}}
+
+	for tick := 1; tick <= 3; tick++ {
+		for _, p := range results {
+			srv.probeTotal.WithLabelValues(probePathString(p.Path), string(p.Category)).Inc()
+		}
+
+		got := testutil.ToFloat64(srv.probeTotal.WithLabelValues("/kernel", string(ProbeCategoryPass)))
+		if got != float64(tick) {
+			t.Fatalf("after tick %d: expected cumulative count %d, got %v", tick, tick, got)
+		}
+	}
+}
+
+func TestProbePathString(t *testing.T) {
+	cases := []struct {
+		path []string
+		want string
+	}{
+		{nil, "/"},
+		{[]string{"kernel"}, "/kernel"},
+		{[]string{"kernel", "cgroups", "v2"}, "/kernel/cgroups/v2"},
+	}
+
+	for _, c := range cases {
+		if got := probePathString(c.path); got != c.want {
+			t.Errorf("probePathString(%v) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
@@ -37,6 +37,9 @@ func NewSysinfoCmd() *cobra.Command {
 
 	var sysinfoSpec sysinfo.K0sSysinfoSpec
 	var outputFormat string
+	var policyLocation string
+	var baselinePath string
+	var saveBaselinePath string
 
 	cmd := &cobra.Command{
 		Use:   "sysinfo",
@@ -47,27 +50,42 @@ func NewSysinfoCmd() *cobra.Command {
 			probes := sysinfoSpec.NewSysinfoProbes()
 			out := cmd.OutOrStdout()
 
+			pol, err := loadPolicy(cmd.Context(), policyLocation)
+			if err != nil {
+				return err
+			}
+
+			if baselinePath != "" || saveBaselinePath != "" {
+				return runWithBaseline(probes, pol, out, outputFormat, baselinePath, saveBaselinePath)
+			}
+
 			switch outputFormat {
 			case "text":
-				cli := &cliReporter{
-					w:      out,
-					colors: aurora.NewAurora(term.IsTerminal(out)),
+				reporter := &policyReporter{
+					next: &cliReporter{
+						w:      out,
+						colors: aurora.NewAurora(term.IsTerminal(out)),
+					},
+					policy: pol,
 				}
-				if err := probes.Probe(cli); err != nil {
+				if err := probes.Probe(reporter); err != nil {
 					return err
 				}
-				if cli.failed {
+				if reporter.failed {
 					return errors.New("sysinfo failed")
 				}
 				return nil
 
 			case "json":
-				return collectAndPrint(probes, out, func(v interface{}) ([]byte, error) {
+				return collectAndPrint(probes, out, pol, func(v interface{}) ([]byte, error) {
 					return json.MarshalIndent(v, "", "  ")
 				})
 
 			case "yaml":
-				return collectAndPrint(probes, out, yaml.Marshal)
+				return collectAndPrint(probes, out, pol, yaml.Marshal)
+
+			case "sarif":
+				return collectAndPrintSarif(probes, out, pol)
 
 			default:
 				return fmt.Errorf("unknown output format: %q", outputFormat)
@@ -75,16 +93,77 @@ func NewSysinfoCmd() *cobra.Command {
 		},
 	}
 
+	cmd.AddCommand(newPolicyCmd())
+	cmd.AddCommand(newServeCmd())
+
 	// append flags
 	flags := cmd.Flags()
 	flags.BoolVar(&sysinfoSpec.ControllerRoleEnabled, "controller", true, "Include controller-specific sysinfo")
 	flags.BoolVar(&sysinfoSpec.WorkerRoleEnabled, "worker", true, "Include worker-specific sysinfo")
 	flags.StringVar(&sysinfoSpec.DataDir, "data-dir", constant.DataDirDefault, "Data Directory for k0s")
-	flags.StringVarP(&outputFormat, "output", "o", "text", "Output format (valid values: text, json, yaml)")
+	flags.StringVarP(&outputFormat, "output", "o", "text", "Output format (valid values: text, json, yaml, sarif)")
+	flags.StringVar(&policyLocation, "policy", "", "Rego policy bundle (file, directory, or URL) to re-grade probe results")
+	flags.StringVar(&baselinePath, "baseline", "", "Diff this run against a baseline file saved by a previous --save-baseline run")
+	flags.StringVar(&saveBaselinePath, "save-baseline", "", "Save this run's results as a baseline file for future --baseline comparisons")
 
 	return cmd
 }
 
+// runWithBaseline runs the probes once and, depending on which of the two
+// flags are set, saves the results as a new baseline and/or diffs them
+// against an existing one. It replaces the normal per-format switch because
+// both operations need the full, unstreamed set of results up front.
+func runWithBaseline(probe probes.Probe, pol *policy, out io.Writer, outputFormat, baselinePath, saveBaselinePath string) error {
+	if baselinePath != "" {
+		switch outputFormat {
+		case "text", "json", "yaml":
+		default:
+			return fmt.Errorf("drift output only supports text, json, or yaml (got %q)", outputFormat)
+		}
+	}
+
+	var c resultsCollector
+	reporter := &policyReporter{next: &c, policy: pol}
+	if err := probe.Probe(reporter); err != nil {
+		return err
+	}
+
+	// Load the old baseline before (potentially) overwriting it below: this
+	// is the only way --baseline and --save-baseline can safely point at the
+	// same file, the natural "diff against last run, then rotate" usage.
+	var baseline *baselineFile
+	if baselinePath != "" {
+		var err error
+		baseline, err = loadBaseline(baselinePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if saveBaselinePath != "" {
+		if err := saveBaseline(saveBaselinePath, c.results); err != nil {
+			return err
+		}
+	}
+
+	if baseline == nil {
+		if reporter.failed {
+			return errors.New("sysinfo failed")
+		}
+		return nil
+	}
+
+	drift := diffBaseline(baseline.Probes, c.results)
+	if err := printDrift(drift, out, outputFormat, aurora.NewAurora(term.IsTerminal(out))); err != nil {
+		return err
+	}
+
+	if hasRegression(drift) {
+		return errors.New("sysinfo baseline drift detected")
+	}
+	return nil
+}
+
 type cliReporter struct {
 	w      io.Writer
 	colors aurora.Aurora
@@ -138,12 +217,13 @@ func (r *cliReporter) Error(p probes.ProbeDesc, err error) error {
 	)
 }
 
-func collectAndPrint(probe probes.Probe, out io.Writer, marshal func(any) ([]byte, error)) error {
+func collectAndPrint(probe probes.Probe, out io.Writer, pol *policy, marshal func(any) ([]byte, error)) error {
 	var c resultsCollector
-	if err := probe.Probe(&c); err != nil {
+	pr := &policyReporter{next: &c, policy: pol}
+	if err := probe.Probe(pr); err != nil {
 		return err
 	}
-	if c.failed {
+	if pr.failed {
 		return errors.New("sysinfo failed")
 	}
 	bytes, err := marshal(c.results)
@@ -167,6 +247,11 @@ type Probe struct {
 	Message     string
 	Category    ProbeCategory
 	Error       error
+
+	// Drift and BaselineProp are only populated on entries produced by
+	// diffBaseline; every other Probe leaves them zero.
+	Drift        string `json:",omitempty"`
+	BaselineProp string `json:",omitempty"`
 }
 
 type ProbeCategory string
@@ -176,6 +261,9 @@ const (
 	ProbeCategoryWarning  ProbeCategory = "warning"
 	ProbeCategoryRejected ProbeCategory = "rejected"
 	ProbeCategoryError    ProbeCategory = "error"
+	// ProbeCategoryDrift marks a synthetic result produced by diffing a
+	// sysinfo run against a previously saved --save-baseline snapshot.
+	ProbeCategoryDrift ProbeCategory = "drift"
 )
 
 type resultsCollector struct {
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+)
+
+// probeFunc adapts a plain function into a probes.Probe.
+type probeFunc func(probes.Reporter) error
+
+func (f probeFunc) Probe(r probes.Reporter) error { return f(r) }
+
+// TestRunWithBaselineSamePathDiffsAgainstOldContents guards against
+// --baseline and --save-baseline pointing at the same file silently diffing
+// a run against itself: the old baseline must be read before it's
+// overwritten, so rotating the baseline on every run still reports drift.
+func TestRunWithBaselineSamePathDiffsAgainstOldContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	seed := probeFunc(func(r probes.Reporter) error {
+		return r.Pass(fakeProbeDesc{path: []string{"memory"}, name: "total"}, staticProbedProp("32Gi"))
+	})
+	if err := runWithBaseline(seed, nil, &bytes.Buffer{}, "text", "", path); err != nil {
+		t.Fatalf("seeding baseline failed: %v", err)
+	}
+
+	drifted := probeFunc(func(r probes.Reporter) error {
+		return r.Pass(fakeProbeDesc{path: []string{"memory"}, name: "total"}, staticProbedProp("16Gi"))
+	})
+
+	var out bytes.Buffer
+	err := runWithBaseline(drifted, nil, &out, "text", path, path)
+	if err != nil {
+		t.Fatalf("a changed value on a passing probe is not a regression, expected no error, got: %v", err)
+	}
+	if !strings.Contains(out.String(), "changed") {
+		t.Fatalf("expected the diff against the pre-overwrite baseline to show the change, got: %q", out.String())
+	}
+}
+
+func TestRunWithBaselineRejectsSarifOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	seed := probeFunc(func(r probes.Reporter) error { return nil })
+	if err := runWithBaseline(seed, nil, &bytes.Buffer{}, "text", "", path); err != nil {
+		t.Fatalf("seeding baseline failed: %v", err)
+	}
+
+	err := runWithBaseline(seed, nil, &bytes.Buffer{}, "sarif", path, "")
+	if err == nil {
+		t.Fatal("expected an error for --baseline combined with -o sarif")
+	}
+	if strings.Contains(err.Error(), "unknown output format") {
+		t.Fatalf("expected a drift-specific error, got the generic unknown-format message: %v", err)
+	}
+}
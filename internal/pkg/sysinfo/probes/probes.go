@@ -0,0 +1,107 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package probes defines the probe tree that k0s's sysinfo pre-flight
+// checks are built from, independent of how results get reported (CLI
+// text, JSON/YAML, SARIF, ...).
+package probes
+
+// ProbeDesc identifies a single probe: its path in the probe tree and a
+// human-readable name.
+type ProbeDesc interface {
+	Path() []string
+	DisplayName() string
+}
+
+// ProbedProp is the value a probe resolved, rendered for display.
+type ProbedProp interface {
+	String() string
+}
+
+// Reporter receives the outcome of each probe as it runs.
+type Reporter interface {
+	Pass(ProbeDesc, ProbedProp) error
+	Warn(ProbeDesc, ProbedProp, string) error
+	Reject(ProbeDesc, ProbedProp, string) error
+	Error(ProbeDesc, error) error
+}
+
+// Probe is anything that can run itself against a Reporter, be it a single
+// check or a whole tree of them.
+type Probe interface {
+	Probe(Reporter) error
+}
+
+// Probes is a named, extensible group of probes.
+type Probes interface {
+	Probe
+
+	// Category returns the named sub-group, creating it if necessary, so
+	// callers can nest probes under e.g. "kernel" or "windows".
+	Category(name string) Probes
+
+	// Set registers a probe under name within this group.
+	Set(name string, probe Probe)
+}
+
+// NewProbes creates an empty, top-level group of probes.
+func NewProbes() Probes {
+	return &group{}
+}
+
+type namedProbe struct {
+	name  string
+	probe Probe
+}
+
+type group struct {
+	path    []string
+	members []namedProbe
+}
+
+func (g *group) Path() []string      { return g.path }
+func (g *group) DisplayName() string { return "" }
+
+func (g *group) Probe(r Reporter) error {
+	for _, m := range g.members {
+		if err := m.probe.Probe(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *group) Category(name string) Probes {
+	for _, m := range g.members {
+		if m.name == name {
+			if sub, ok := m.probe.(Probes); ok {
+				return sub
+			}
+		}
+	}
+
+	path := make([]string, 0, len(g.path)+1)
+	path = append(path, g.path...)
+	path = append(path, name)
+
+	sub := &group{path: path}
+	g.members = append(g.members, namedProbe{name: name, probe: sub})
+	return sub
+}
+
+func (g *group) Set(name string, probe Probe) {
+	g.members = append(g.members, namedProbe{name: name, probe: probe})
+}
@@ -0,0 +1,184 @@
+//go:build windows
+
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package windows provides the worker-side sysinfo probes that only make
+// sense on Windows nodes: HCS/containerd readiness and the kube-proxy/CNI
+// prerequisite services. K0sSysinfoSpec.NewSysinfoProbes wires RegisterProbes
+// into the worker probe tree on GOOS=windows builds; on every other platform
+// this package isn't even compiled, so it carries no cost for the Linux
+// binary.
+package windows
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+
+	"github.com/Microsoft/hcsshim"
+	"github.com/Microsoft/hcsshim/osversion"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// minBuildNumber is the oldest Windows build number known to work with the
+// containerd/HCS combination k0s pins on Windows workers.
+const minBuildNumber = 17763 // Windows Server 2019 (1809)
+
+// RegisterProbes adds the Windows worker probes to the given probe group.
+func RegisterProbes(parent probes.Probes) {
+	windows := parent.Category("windows")
+
+	windows.Set("buildnumber", buildNumberProbe{})
+	windows.Set("hcs", hcsServiceProbe{})
+	windows.Set("containerd", containerdProbe{})
+	windows.Set("hns", serviceProbe{name: "hns", displayName: "HNS service"})
+	windows.Set("vfpext", serviceProbe{name: "vfpext", displayName: "VFP forwarding extension"})
+}
+
+// buildNumberProp is a probes.ProbedProp carrying the resolved build number.
+type buildNumberProp uint32
+
+func (p buildNumberProp) String() string { return fmt.Sprintf("%d", uint32(p)) }
+
+type buildNumberProbe struct{}
+
+func (buildNumberProbe) Path() []string      { return []string{"windows", "buildnumber"} }
+func (buildNumberProbe) DisplayName() string { return "Windows build number" }
+
+func (p buildNumberProbe) Probe(r probes.Reporter) error {
+	build := osversion.Build()
+	prop := buildNumberProp(build)
+
+	if build < minBuildNumber {
+		return r.Reject(p, prop, fmt.Sprintf("build %d is older than the minimum supported build %d", build, minBuildNumber))
+	}
+	return r.Pass(p, prop)
+}
+
+// stringProp is a plain string probes.ProbedProp.
+type stringProp string
+
+func (p stringProp) String() string { return string(p) }
+
+// hcsServiceName is the Windows service backing the Host Compute Service.
+const hcsServiceName = "vmcompute"
+
+// hyperVServiceName is the Virtual Machine Management Service; its presence
+// indicates the host has the Hyper-V role installed and can run
+// Hyper-V-isolated containers in addition to process-isolated ones.
+const hyperVServiceName = "vmms"
+
+type hcsServiceProbe struct{}
+
+func (hcsServiceProbe) Path() []string      { return []string{"windows", "hcs"} }
+func (hcsServiceProbe) DisplayName() string { return "Host Compute Service" }
+
+func (p hcsServiceProbe) Probe(r probes.Reporter) error {
+	running, err := windowsServiceRunning(hcsServiceName)
+	if err != nil {
+		return r.Error(p, fmt.Errorf("failed to query the Host Compute Service: %w", err))
+	}
+	if !running {
+		return r.Reject(p, nil, "the Host Compute Service (vmcompute) is not running")
+	}
+
+	// A basic functional check: ask the HCS driver for a layer's mount
+	// path. The layer itself doesn't need to exist; a response (even a
+	// "not found" one) means the service answered the request.
+	if _, err := hcsshim.GetLayerMountPath(hcsshim.DriverInfo{HomeDir: os.TempDir()}, "k0s-sysinfo-probe"); err != nil && !os.IsNotExist(err) {
+		return r.Reject(p, nil, fmt.Sprintf("the Host Compute Service did not respond to a layer query: %v", err))
+	}
+
+	hyperV, err := windowsServiceRunning(hyperVServiceName)
+	if err != nil {
+		return r.Error(p, fmt.Errorf("failed to query the Hyper-V management service: %w", err))
+	}
+
+	mode := "process-isolation"
+	if hyperV {
+		mode = "hyperv-isolation"
+	}
+	return r.Pass(p, stringProp(mode))
+}
+
+type containerdProbe struct{}
+
+func (containerdProbe) Path() []string      { return []string{"windows", "containerd"} }
+func (containerdProbe) DisplayName() string { return "containerd" }
+
+func (p containerdProbe) Probe(r probes.Reporter) error {
+	path, err := exec.LookPath("containerd.exe")
+	if err != nil {
+		return r.Reject(p, nil, "containerd.exe was not found on PATH")
+	}
+
+	out, err := exec.Command(path, "--version").Output()
+	if err != nil {
+		return r.Error(p, fmt.Errorf("failed to determine containerd version: %w", err))
+	}
+
+	return r.Pass(p, stringProp(strings.TrimSpace(string(out))))
+}
+
+// serviceProbe asserts that a named Windows service exists and is running.
+type serviceProbe struct {
+	name        string
+	displayName string
+}
+
+func (p serviceProbe) Path() []string      { return []string{"windows", p.name} }
+func (p serviceProbe) DisplayName() string { return p.displayName }
+
+func (p serviceProbe) Probe(r probes.Reporter) error {
+	running, err := windowsServiceRunning(p.name)
+	if err != nil {
+		return r.Error(p, err)
+	}
+	if !running {
+		return r.Reject(p, nil, fmt.Sprintf("service %q is not running", p.name))
+	}
+
+	return r.Pass(p, stringProp("running"))
+}
+
+// windowsServiceRunning reports whether the named Windows service is
+// currently installed and running, using the real service-manager API
+// (golang.org/x/sys/windows/svc/mgr) rather than package-specific helpers.
+func windowsServiceRunning(name string) (bool, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	service, err := m.OpenService(name)
+	if err != nil {
+		return false, nil
+	}
+	defer service.Close()
+
+	status, err := service.Query()
+	if err != nil {
+		return false, fmt.Errorf("failed to query service %q: %w", name, err)
+	}
+
+	return status.State == svc.Running, nil
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import "github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+
+// K0sSysinfoSpec describes which sysinfo probes to run for a given node.
+type K0sSysinfoSpec struct {
+	ControllerRoleEnabled bool
+	WorkerRoleEnabled     bool
+	DataDir               string
+	AddDebugProbes        bool
+}
+
+// NewSysinfoProbes assembles the probe tree for this spec. Platform-specific
+// worker probes are added by addWorkerProbes, which has a separate
+// implementation per GOOS (see sysinfo_windows.go).
+func (s *K0sSysinfoSpec) NewSysinfoProbes() probes.Probes {
+	p := probes.NewProbes()
+
+	addWorkerProbes(p, s)
+
+	return p
+}
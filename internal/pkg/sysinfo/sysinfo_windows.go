@@ -0,0 +1,34 @@
+//go:build windows
+
+/*
+Copyright 2021 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sysinfo
+
+import (
+	"github.com/k0sproject/k0s/internal/pkg/sysinfo/probes"
+	windowsprobes "github.com/k0sproject/k0s/internal/pkg/sysinfo/probes/windows"
+)
+
+// addWorkerProbes registers the Windows-specific worker probes (HCS,
+// containerd, kube-proxy/CNI prerequisite services) when this node is
+// configured to run as a worker.
+func addWorkerProbes(p probes.Probes, spec *K0sSysinfoSpec) {
+	if !spec.WorkerRoleEnabled {
+		return
+	}
+	windowsprobes.RegisterProbes(p)
+}